@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// alpacaBarsResponse is the JSON shape returned by Alpaca's
+// GET /v2/stocks/{symbol}/bars endpoint.
+type alpacaBarsResponse struct {
+	Bars []struct {
+		Timestamp string  `json:"t"`
+		Open      float64 `json:"o"`
+		High      float64 `json:"h"`
+		Low       float64 `json:"l"`
+		Close     float64 `json:"c"`
+		Volume    int64   `json:"v"`
+	} `json:"bars"`
+	NextPageToken string `json:"next_page_token"`
+}
+
+// AlpacaProvider fetches daily bars from Alpaca's Data API v2.
+type AlpacaProvider struct {
+	Client *http.Client
+	KeyID  string
+	Secret string
+}
+
+func (p *AlpacaProvider) FetchDaily(ctx context.Context, ticker string, from, to time.Time) ([]Bar, error) {
+	var bars []Bar
+	pageToken := ""
+
+	for {
+		url := fmt.Sprintf(
+			"https://data.alpaca.markets/v2/stocks/%s/bars?timeframe=1Day&start=%s&end=%s&limit=10000",
+			ticker, from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339))
+		if pageToken != "" {
+			url += "&page_token=" + pageToken
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating HTTP request: %v", err)
+		}
+		req.Header.Set("APCA-API-KEY-ID", p.KeyID)
+		req.Header.Set("APCA-API-SECRET-KEY", p.Secret)
+
+		resp, err := doWithRetry(p.Client, req)
+		if err != nil {
+			return nil, fmt.Errorf("error making HTTP request: %v", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("received status code %d", resp.StatusCode)
+		}
+
+		var page alpacaBarsResponse
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding JSON: %v", err)
+		}
+
+		for _, b := range page.Bars {
+			ts, err := time.Parse(time.RFC3339, b.Timestamp)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing bar timestamp: %v", err)
+			}
+			bars = append(bars, Bar{
+				Timestamp: ts,
+				Open:      b.Open,
+				High:      b.High,
+				Low:       b.Low,
+				Close:     b.Close,
+				Volume:    b.Volume,
+			})
+		}
+
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+
+	return bars, nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// warnOnCacheError logs a failed cache write to stderr instead of dropping
+// it silently; a stale/missing cache only costs a re-fetch, but a disk-full
+// or permissions failure is worth surfacing to the user.
+func warnOnCacheError(ticker, rangeStr string, err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to cache %s %s: %v\n", ticker, rangeStr, err)
+	}
+}
+
+// cacheEntry is what's persisted to disk for a single (ticker, range,
+// interval) fetch: the decoded bars plus enough of the response to make a
+// conditional request next time.
+type cacheEntry struct {
+	LastModified string    `json:"last_modified"`
+	FetchedAt    time.Time `json:"fetched_at"`
+	Bars         []Bar     `json:"bars"`
+}
+
+// chartCache is an on-disk cache for daily-bar responses, gzipped JSON under
+// $XDG_CACHE_HOME/go_fintech_avgs (or ~/.cache/go_fintech_avgs).
+type chartCache struct {
+	dir string
+}
+
+func newChartCache() (*chartCache, error) {
+	base := os.Getenv("XDG_CACHE_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("error resolving cache directory: %v", err)
+		}
+		base = filepath.Join(home, ".cache")
+	}
+	dir := filepath.Join(base, "go_fintech_avgs")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating cache directory: %v", err)
+	}
+	return &chartCache{dir: dir}, nil
+}
+
+// cacheKey identifies a cached fetch by ticker, range and interval.
+func cacheKey(ticker, rangeStr, interval string) string {
+	sum := sha1.Sum([]byte(ticker + "|" + rangeStr + "|" + interval))
+	return fmt.Sprintf("%x", sum)
+}
+
+func (c *chartCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json.gz")
+}
+
+// load reads the cache entry for key, if any. ok is false if nothing is
+// cached or the cached file can't be read.
+func (c *chartCache) load(key string) (entry cacheEntry, ok bool) {
+	f, err := os.Open(c.path(key))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	defer gr.Close()
+
+	if err := json.NewDecoder(gr).Decode(&entry); err != nil {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// store writes entry for key, replacing any existing cached file.
+func (c *chartCache) store(key string, entry cacheEntry) error {
+	f, err := os.Create(c.path(key))
+	if err != nil {
+		return fmt.Errorf("error creating cache file: %v", err)
+	}
+	gw := gzip.NewWriter(f)
+	if err := json.NewEncoder(gw).Encode(entry); err != nil {
+		gw.Close()
+		f.Close()
+		return err
+	}
+	// Closing the gzip writer flushes the compressed data, so its error (and
+	// the file's) is where a disk-full failure shows up.
+	if err := gw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// fresh reports whether entry is still within ttl of when it was fetched.
+func (entry cacheEntry) fresh(ttl time.Duration) bool {
+	return time.Since(entry.FetchedAt) < ttl
+}
+
+// cacheTTL returns how long a cached fetch for [from, to] should be trusted
+// before revalidating: short ranges (ytd/1y) move daily after market close,
+// long ranges (3y/5y/10y) only need a weekly refresh.
+func cacheTTL(from, to time.Time) time.Duration {
+	if to.Sub(from) <= 366*24*time.Hour {
+		return 24 * time.Hour
+	}
+	return 7 * 24 * time.Hour
+}
+
+// conditionalProvider is implemented by providers that can revalidate a
+// cached fetch with the upstream API instead of always re-fetching in full.
+type conditionalProvider interface {
+	FetchDailyConditional(ctx context.Context, ticker string, from, to time.Time, lastModified string) (bars []Bar, newLastModified string, notModified bool, err error)
+}
+
+// fetchWithCache serves ticker/rangeStr bars out of cache when they're still
+// fresh, revalidates with the provider when it supports conditional fetches
+// (currently only YahooChartProvider), and otherwise falls back to a plain
+// provider.FetchDaily. It is a no-op passthrough when cache is nil.
+func fetchWithCache(ctx context.Context, cache *chartCache, provider MarketDataProvider, ticker, rangeStr string, from, to time.Time, refresh, offline bool) ([]Bar, error) {
+	if cache == nil {
+		return provider.FetchDaily(ctx, ticker, from, to)
+	}
+
+	key := cacheKey(ticker, rangeStr, "1d")
+	entry, hasEntry := cache.load(key)
+	ttl := cacheTTL(from, to)
+
+	if hasEntry && !refresh && entry.fresh(ttl) {
+		return entry.Bars, nil
+	}
+	if offline {
+		if hasEntry {
+			return entry.Bars, nil
+		}
+		return nil, fmt.Errorf("offline mode: no cached data for %s %s", ticker, rangeStr)
+	}
+
+	cp, ok := provider.(conditionalProvider)
+	if !ok {
+		bars, err := provider.FetchDaily(ctx, ticker, from, to)
+		if err != nil {
+			return nil, err
+		}
+		warnOnCacheError(ticker, rangeStr, cache.store(key, cacheEntry{FetchedAt: time.Now(), Bars: bars}))
+		return bars, nil
+	}
+
+	lastModified := ""
+	if hasEntry && !refresh {
+		lastModified = entry.LastModified
+	}
+	bars, newLastModified, notModified, err := cp.FetchDailyConditional(ctx, ticker, from, to, lastModified)
+	if err != nil {
+		return nil, err
+	}
+	if notModified {
+		entry.FetchedAt = time.Now()
+		warnOnCacheError(ticker, rangeStr, cache.store(key, entry))
+		return entry.Bars, nil
+	}
+	warnOnCacheError(ticker, rangeStr, cache.store(key, cacheEntry{LastModified: newLastModified, FetchedAt: time.Now(), Bars: bars}))
+	return bars, nil
+}
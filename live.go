@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// alpacaStreamURL is Alpaca's Data v2 IEX minute-bar websocket feed.
+const alpacaStreamURL = "wss://stream.data.alpaca.markets/v2/iex"
+
+// tickerLiveState tracks the intraday picture for a single ticker as minute
+// bars arrive over the websocket.
+type tickerLiveState struct {
+	mu sync.Mutex
+
+	open      float64
+	haveOpen  bool
+	sumAbsPct float64
+	barCount  int
+}
+
+func (s *tickerLiveState) applyBar(open, close float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.haveOpen {
+		s.open = open
+		s.haveOpen = true
+	}
+	if s.open == 0 {
+		return
+	}
+	movePct := ((close - s.open) / s.open) * 100.0
+	s.sumAbsPct += math.Abs(movePct)
+	s.barCount++
+}
+
+// snapshot returns the running average absolute move for today. hasData is
+// false until the first bar has arrived.
+func (s *tickerLiveState) snapshot() (avgAbsPct float64, hasData bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.barCount == 0 {
+		return 0, false
+	}
+	return s.sumAbsPct / float64(s.barCount), true
+}
+
+// alpacaAuthMsg and alpacaSubscribeMsg are the control messages the Alpaca
+// Data v2 websocket expects on connect.
+type alpacaAuthMsg struct {
+	Action string `json:"action"`
+	Key    string `json:"key"`
+	Secret string `json:"secret"`
+}
+
+type alpacaSubscribeMsg struct {
+	Action string   `json:"action"`
+	Bars   []string `json:"bars"`
+}
+
+// alpacaBarMsg is a single "b" (minute bar) message from the stream.
+type alpacaBarMsg struct {
+	Type   string  `json:"T"`
+	Symbol string  `json:"S"`
+	Open   float64 `json:"o"`
+	Close  float64 `json:"c"`
+}
+
+// runLiveMode opens the Alpaca bar stream for tickers, maintains rolling
+// per-ticker intraday state, and redraws a live matrix every redrawEvery
+// alongside the historical thresholds already computed for each ticker. It
+// blocks until the process is interrupted, reconnecting with exponential
+// backoff and resubscribing whenever the connection drops.
+func runLiveMode(tickers []string, thresholds map[string]float64, redrawEvery time.Duration) error {
+	keyID := os.Getenv("APCA_API_KEY_ID")
+	secret := os.Getenv("APCA_API_SECRET_KEY")
+	if keyID == "" || secret == "" {
+		return fmt.Errorf("live mode requires APCA_API_KEY_ID and APCA_API_SECRET_KEY to be set")
+	}
+
+	states := make(map[string]*tickerLiveState, len(tickers))
+	for _, t := range tickers {
+		states[t] = &tickerLiveState{}
+	}
+
+	go drawLiveMatrix(tickers, states, thresholds, redrawEvery)
+
+	backoff := time.Second
+	for {
+		err := streamOnce(keyID, secret, tickers, states)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "live stream error: %v (reconnecting in %s)\n", err, backoff)
+		}
+		time.Sleep(backoff + time.Duration(rand.Int63n(int64(backoff)/2+1)))
+		backoff *= 2
+		if backoff > 30*time.Second {
+			backoff = 30 * time.Second
+		}
+	}
+}
+
+// streamOnce opens a single websocket connection, authenticates, subscribes
+// to minute bars for tickers, and applies incoming bars to states until the
+// connection closes or errors.
+func streamOnce(keyID, secret string, tickers []string, states map[string]*tickerLiveState) error {
+	conn, _, err := websocket.DefaultDialer.Dial(alpacaStreamURL, nil)
+	if err != nil {
+		return fmt.Errorf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteJSON(alpacaAuthMsg{Action: "auth", Key: keyID, Secret: secret}); err != nil {
+		return fmt.Errorf("auth: %v", err)
+	}
+	if err := conn.WriteJSON(alpacaSubscribeMsg{Action: "subscribe", Bars: tickers}); err != nil {
+		return fmt.Errorf("subscribe: %v", err)
+	}
+
+	for {
+		var msgs []alpacaBarMsg
+		if err := conn.ReadJSON(&msgs); err != nil {
+			return fmt.Errorf("read: %v", err)
+		}
+		for _, msg := range msgs {
+			if msg.Type != "b" {
+				continue
+			}
+			if state, ok := states[msg.Symbol]; ok {
+				state.applyBar(msg.Open, msg.Close)
+			}
+		}
+	}
+}
+
+// drawLiveMatrix redraws, every redrawEvery until the process exits, a table
+// with one row per ticker: its 1y threshold, today's running average absolute
+// move, and whether that move exceeds the threshold's magnitude. The
+// historical timeframe matrix itself is not redrawn. Tickers with no entry in
+// thresholds (their historical "1y" fetch failed) are shown as N/A rather
+// than silently comparing against a threshold of 0.
+func drawLiveMatrix(tickers []string, states map[string]*tickerLiveState, thresholds map[string]float64, redrawEvery time.Duration) {
+	for range time.Tick(redrawEvery) {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Ticker\tThreshold\tToday Avg Abs\tRed Day?")
+		fmt.Fprintln(w, "------\t---------\t-------------\t--------")
+		for _, tkr := range tickers {
+			avgAbsPct, hasData := states[tkr].snapshot()
+			threshold, hasThreshold := thresholds[tkr]
+
+			thresholdCell, avgCell, status := "N/A", "N/A", "N/A"
+			if hasData {
+				avgCell = fmt.Sprintf("%.2f%%", avgAbsPct)
+			}
+			if hasThreshold {
+				thresholdCell = fmt.Sprintf("%.2f%%", threshold)
+				status = "no"
+				if hasData && avgAbsPct > math.Abs(threshold) {
+					status = "YES"
+				}
+			}
+
+			fmt.Fprintln(w, strings.Join([]string{tkr, thresholdCell, avgCell, status}, "\t"))
+		}
+		w.Flush()
+	}
+}
@@ -1,110 +1,90 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
 	"math"
-	"net/http"
 	"os"
+	"runtime"
 	"strings"
 	"sync"
-	"text/tabwriter"
 	"time"
 )
 
-// ChartResponse represents the JSON response from the Yahoo Finance chart API.
-type ChartResponse struct {
-	Chart struct {
-		Result []struct {
-			Timestamp  []int64 `json:"timestamp"`
-			Indicators struct {
-				Quote []struct {
-					Open  []float64 `json:"open"`
-					Close []float64 `json:"close"`
-				} `json:"quote"`
-			} `json:"indicators"`
-		} `json:"result"`
-		Error interface{} `json:"error"`
-	} `json:"chart"`
-}
-
-// analyzeTimeframe performs the calculations for a given ticker and range (e.g., "ytd", "1y", "3y", "5y", "10y").
-func analyzeTimeframe(ticker, rangeStr string) (avgAbsPct float64, avgMonthlyRedDays float64, err error) {
-	// Build the Yahoo Finance chart API URL.
-	url := fmt.Sprintf("https://query1.finance.yahoo.com/v8/finance/chart/%s?range=%s&interval=1d", ticker, rangeStr)
+// analyzeTimeframe performs the calculations for a given ticker and range
+// (e.g., "ytd", "1y", "3y", "5y", "10y") using the supplied provider to fetch
+// the underlying daily bars, and the given threshold model to decide which
+// days count as "red". ctx bounds the underlying fetch with the caller's
+// per-request timeout and the run's global deadline.
+func analyzeTimeframe(ctx context.Context, provider MarketDataProvider, cache *chartCache, ticker, rangeStr string, refresh, offline bool, spec thresholdSpec) (TimeframeResult, error) {
+	res := TimeframeResult{Ticker: ticker, Timeframe: rangeStr}
 
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", url, nil)
+	from, to, err := rangeToDates(rangeStr)
 	if err != nil {
-		return 0, 0, fmt.Errorf("Error creating HTTP request: %v", err)
+		return res, err
 	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) "+
-		"AppleWebKit/537.36 (KHTML, like Gecko) Chrome/115.0.0.0 Safari/537.36")
 
-	resp, err := client.Do(req)
+	bars, err := fetchWithCache(ctx, cache, provider, ticker, rangeStr, from, to, refresh, offline)
 	if err != nil {
-		return 0, 0, fmt.Errorf("Error making HTTP request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return 0, 0, fmt.Errorf("Received status code %d", resp.StatusCode)
-	}
-
-	var chartResp ChartResponse
-	if err := json.NewDecoder(resp.Body).Decode(&chartResp); err != nil {
-		return 0, 0, fmt.Errorf("Error decoding JSON: %v", err)
-	}
-	if len(chartResp.Chart.Result) == 0 {
-		return 0, 0, fmt.Errorf("No result in chart response")
-	}
-
-	result := chartResp.Chart.Result[0]
-	timestamps := result.Timestamp
-	opens := result.Indicators.Quote[0].Open
-	closes := result.Indicators.Quote[0].Close
-
-	if len(timestamps) != len(opens) || len(timestamps) != len(closes) {
-		return 0, 0, fmt.Errorf("Mismatch in data lengths")
+		return res, err
 	}
 
 	// First pass: Compute the average absolute daily move in percentage.
 	var totalAbsPct float64
 	validCount := 0.0
-	dailyPct := make([]float64, len(timestamps))
+	dailyPct := make([]float64, len(bars))
+	validDailyPct := make([]float64, 0, len(bars))
+	validBars := make([]Bar, 0, len(bars))
 	monthDays := make(map[string]int)
 
-	for i, ts := range timestamps {
-		if opens[i] == 0 {
+	for i, bar := range bars {
+		if bar.Open == 0 {
 			continue
 		}
-		movePct := ((closes[i] - opens[i]) / opens[i]) * 100.0
+		movePct := ((bar.Close - bar.Open) / bar.Open) * 100.0
 		dailyPct[i] = movePct
+		validDailyPct = append(validDailyPct, movePct)
+		validBars = append(validBars, bar)
 		totalAbsPct += math.Abs(movePct)
 		validCount++
+		if movePct < res.BiggestDrop {
+			res.BiggestDrop = movePct
+		}
 
-		t := time.Unix(ts, 0)
-		monthKey := t.Format("2006-01")
+		monthKey := bar.Timestamp.Format("2006-01")
 		monthDays[monthKey]++
 	}
 	if validCount == 0 {
-		return 0, 0, fmt.Errorf("No valid trading days found")
+		return res, fmt.Errorf("no valid trading days found")
 	}
-	avgAbsPct = totalAbsPct / validCount
+	res.AvgAbsDailyMove = totalAbsPct / validCount
 
-	// Red day threshold: a red day is one where the daily move is less than -60% of the average absolute move.
-	threshold := -1 * avgAbsPct
+	// redDayThreshold and maxConsecutiveRedStreak get the gap-filtered
+	// validDailyPct/validBars, not the raw dailyPct/bars: a data-gap bar
+	// (bar.Open == 0) decodes with every OHLC field zero, which would
+	// otherwise be read as an exact 0% move or a 0,0,0 true-range bar and
+	// skew the stddev/percentile/ATR models, the streak count, and drawdown.
+	threshold, err := redDayThreshold(validBars, validDailyPct, spec)
+	if err != nil {
+		return res, err
+	}
+	res.Threshold = threshold
+	for i := len(bars) - 1; i >= 0; i-- {
+		if bars[i].Open != 0 {
+			res.LatestMovePct = dailyPct[i]
+			break
+		}
+	}
 
 	// Second pass: Count red days per month.
 	redDaysByMonth := make(map[string]int)
-	for i, ts := range timestamps {
-		if opens[i] == 0 {
+	for i, bar := range bars {
+		if bar.Open == 0 {
 			continue
 		}
 		if dailyPct[i] < threshold {
-			t := time.Unix(ts, 0)
-			monthKey := t.Format("2006-01")
+			monthKey := bar.Timestamp.Format("2006-01")
 			redDaysByMonth[monthKey]++
 		}
 	}
@@ -116,9 +96,13 @@ func analyzeTimeframe(ticker, rangeStr string) (avgAbsPct float64, avgMonthlyRed
 		monthsCounted++
 		totalRedDays += redDaysByMonth[month]
 	}
-	avgMonthlyRedDays = math.Round(float64(totalRedDays) / float64(monthsCounted))
+	res.AvgMonthlyRedDays = math.Round(float64(totalRedDays) / float64(monthsCounted))
 
-	return avgAbsPct, avgMonthlyRedDays, nil
+	res.MaxRedStreak = maxConsecutiveRedStreak(validDailyPct, threshold)
+	res.RedDaysP50, res.RedDaysP90, res.RedDaysMax = monthlyRedDayStats(redDaysByMonth, monthDays)
+	res.MaxDrawdownPct = maxDrawdownPct(validBars)
+
+	return res, nil
 }
 
 type TimeframeResult struct {
@@ -126,12 +110,32 @@ type TimeframeResult struct {
 	Timeframe         string
 	AvgAbsDailyMove   float64
 	AvgMonthlyRedDays float64
+	MaxRedStreak      int
+	RedDaysP50        float64
+	RedDaysP90        float64
+	RedDaysMax        int
+	BiggestDrop       float64
+	MaxDrawdownPct    float64
+	Threshold         float64
+	LatestMovePct     float64
 	Err               error
 }
 
 func main() {
 	// Accept multiple tickers as a comma-separated list.
 	tickersFlag := flag.String("tickers", "", "Comma-separated list of ticker symbols (e.g., NVDA,GOOG,MSFT)")
+	providerFlag := flag.String("provider", "yahoo", "Market data provider to use: yahoo, yahoo-crumb, or alpaca")
+	liveFlag := flag.Bool("live", false, "After the historical analysis, stream Alpaca minute bars and track today's red-day status")
+	liveIntervalFlag := flag.Duration("live-interval", 10*time.Second, "How often to redraw the live matrix in -live mode")
+	refreshFlag := flag.Bool("refresh", false, "Bypass the on-disk cache and force a full re-fetch from the provider")
+	offlineFlag := flag.Bool("offline", false, "Forbid network access; serve only from the on-disk cache")
+	thresholdFlag := flag.String("threshold", "mean-abs", "Red-day threshold model: mean-abs, stddev:<k>, atr:<k>, or percentile:<p>")
+	viewFlag := flag.String("view", "matrix", "Output layout: matrix (compact table) or nested (full per-ticker/timeframe breakdown)")
+	concurrencyFlag := flag.Int("concurrency", 0, "Max number of (ticker, timeframe) fetches to run at once (default min(8, 2*NumCPU))")
+	timeoutFlag := flag.Duration("timeout", 30*time.Second, "Per-request timeout for a single (ticker, timeframe) fetch")
+	deadlineFlag := flag.Duration("deadline", 0, "Overall deadline for the whole run; 0 means no deadline")
+	formatFlag := flag.String("format", "table", "Output format: table, json, csv, or slack")
+	webhookFlag := flag.String("webhook", "", "Slack incoming webhook URL to POST the -format slack payload to")
 	flag.Parse()
 	if *tickersFlag == "" {
 		fmt.Println("Please provide at least one ticker using the -tickers flag.")
@@ -142,66 +146,130 @@ func main() {
 		tickers[i] = strings.TrimSpace(tickers[i])
 	}
 
+	concurrency := *concurrencyFlag
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU() * 2
+		if concurrency > 8 {
+			concurrency = 8
+		}
+	}
+
+	provider, err := newProvider(*providerFlag, newHTTPClient(concurrency))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	rootCtx := context.Background()
+	if *deadlineFlag > 0 {
+		var cancel context.CancelFunc
+		rootCtx, cancel = context.WithTimeout(rootCtx, *deadlineFlag)
+		defer cancel()
+	}
+
+	cache, err := newChartCache()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	spec, err := parseThreshold(*thresholdFlag)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
 	// Define the timeframes: ytd, 1y, 3y, 5y, and 10y.
 	timeframes := []string{"ytd", "1y", "3y", "5y", "10y"}
-	resultsChan := make(chan TimeframeResult, len(tickers)*len(timeframes))
-	var wg sync.WaitGroup
 
-	// Launch a goroutine for each ticker and timeframe.
+	type job struct {
+		ticker    string
+		timeframe string
+	}
+	jobs := make(chan job, len(tickers)*len(timeframes))
 	for _, tkr := range tickers {
 		for _, tf := range timeframes {
-			wg.Add(1)
-			go func(ticker, timeframe string) {
-				defer wg.Done()
-				avgPct, avgRed, err := analyzeTimeframe(ticker, timeframe)
-				resultsChan <- TimeframeResult{
-					Ticker:            ticker,
-					Timeframe:         timeframe,
-					AvgAbsDailyMove:   avgPct,
-					AvgMonthlyRedDays: avgRed,
-					Err:               err,
-				}
-			}(tkr, tf)
+			jobs <- job{ticker: tkr, timeframe: tf}
 		}
 	}
+	close(jobs)
+
+	resultsChan := make(chan TimeframeResult, len(tickers)*len(timeframes))
+	var wg sync.WaitGroup
+
+	// Run a bounded pool of workers over the jobs instead of one goroutine
+	// per (ticker, timeframe); an unbounded fan-out triggers Yahoo 429s on
+	// large ticker lists.
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				reqCtx, cancel := context.WithTimeout(rootCtx, *timeoutFlag)
+				res, err := analyzeTimeframe(reqCtx, provider, cache, j.ticker, j.timeframe, *refreshFlag, *offlineFlag, spec)
+				cancel()
+				res.Err = err
+				resultsChan <- res
+			}
+		}()
+	}
 
 	wg.Wait()
 	close(resultsChan)
 
-	// Arrange results in a matrix: rows = timeframes, columns = tickers.
-	matrix := make(map[string]map[string]string)
-	for _, tf := range timeframes {
-		matrix[tf] = make(map[string]string)
-	}
+	allResults := make([]TimeframeResult, 0, len(tickers)*len(timeframes))
 	for res := range resultsChan {
-		if res.Err != nil {
-			matrix[res.Timeframe][res.Ticker] = "ERR"
-		} else {
-			// Format cell: "Avg Abs: X.XX% / Red: Y days"
-			matrix[res.Timeframe][res.Ticker] = fmt.Sprintf("Avg Abs: %.2f%% / Red: %.0f days", res.AvgAbsDailyMove, res.AvgMonthlyRedDays)
+		allResults = append(allResults, res)
+	}
+
+	switch *formatFlag {
+	case "json":
+		records := make([]resultRecord, 0, len(allResults))
+		for _, res := range allResults {
+			records = append(records, toRecord(res))
+		}
+		if err := writeJSON(os.Stdout, records); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	case "csv":
+		if err := writeCSV(os.Stdout, allResults); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	case "slack":
+		payload := slackPayload(tickers, allResults)
+		if *webhookFlag != "" {
+			webhookCtx, cancel := context.WithTimeout(rootCtx, *timeoutFlag)
+			err := postSlackWebhook(webhookCtx, *webhookFlag, payload)
+			cancel()
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		} else if err := writeJSON(os.Stdout, payload); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	default:
+		switch *viewFlag {
+		case "nested":
+			printNestedView(os.Stdout, tickers, timeframes, allResults)
+		default:
+			printMatrixView(os.Stdout, tickers, timeframes, allResults)
 		}
 	}
 
-	// Print the table with rows as timeframes and columns as tickers.
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	// Header row.
-	header := "Timeframe\t"
-	for _, tkr := range tickers {
-		header += fmt.Sprintf("%s\t", tkr)
-	}
-	fmt.Fprintln(w, header)
-	fmt.Fprintln(w, "---------\t"+strings.Repeat("---------\t", len(tickers)))
-	// Data rows.
-	for _, tf := range timeframes {
-		row := fmt.Sprintf("%s\t", tf)
-		for _, tkr := range tickers {
-			cell, ok := matrix[tf][tkr]
-			if !ok {
-				cell = "N/A"
+	if *liveFlag {
+		thresholds := make(map[string]float64, len(tickers))
+		for _, res := range allResults {
+			if res.Timeframe == "1y" && res.Err == nil {
+				thresholds[res.Ticker] = res.Threshold
 			}
-			row += fmt.Sprintf("%s\t", cell)
 		}
-		fmt.Fprintln(w, row)
+		if err := runLiveMode(tickers, thresholds, *liveIntervalFlag); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 	}
-	w.Flush()
 }
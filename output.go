@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// resultRecord is the machine-readable shape of a TimeframeResult: numeric
+// fields only, with Err flattened to a string so -format json/csv don't
+// depend on error's unexported representation.
+type resultRecord struct {
+	Ticker            string  `json:"ticker"`
+	Timeframe         string  `json:"timeframe"`
+	AvgAbsDailyMove   float64 `json:"avg_abs_daily_move_pct"`
+	AvgMonthlyRedDays float64 `json:"avg_monthly_red_days"`
+	MaxRedStreak      int     `json:"max_red_streak"`
+	RedDaysP50        float64 `json:"red_days_per_month_p50"`
+	RedDaysP90        float64 `json:"red_days_per_month_p90"`
+	RedDaysMax        int     `json:"red_days_per_month_max"`
+	BiggestDrop       float64 `json:"biggest_single_day_drop_pct"`
+	MaxDrawdownPct    float64 `json:"max_drawdown_pct"`
+	Threshold         float64 `json:"threshold_pct"`
+	LatestMovePct     float64 `json:"latest_move_pct"`
+	Error             string  `json:"error,omitempty"`
+}
+
+func toRecord(res TimeframeResult) resultRecord {
+	rec := resultRecord{
+		Ticker:            res.Ticker,
+		Timeframe:         res.Timeframe,
+		AvgAbsDailyMove:   res.AvgAbsDailyMove,
+		AvgMonthlyRedDays: res.AvgMonthlyRedDays,
+		MaxRedStreak:      res.MaxRedStreak,
+		RedDaysP50:        res.RedDaysP50,
+		RedDaysP90:        res.RedDaysP90,
+		RedDaysMax:        res.RedDaysMax,
+		BiggestDrop:       res.BiggestDrop,
+		MaxDrawdownPct:    res.MaxDrawdownPct,
+		Threshold:         res.Threshold,
+		LatestMovePct:     res.LatestMovePct,
+	}
+	if res.Err != nil {
+		rec.Error = res.Err.Error()
+	}
+	return rec
+}
+
+// writeJSON encodes v as indented JSON. It's used both for -format json
+// (an array of resultRecord) and -format slack (a Block Kit payload).
+func writeJSON(out io.Writer, v interface{}) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeCSV writes one row per (ticker, timeframe) result.
+func writeCSV(out io.Writer, results []TimeframeResult) error {
+	w := csv.NewWriter(out)
+	defer w.Flush()
+
+	header := []string{
+		"ticker", "timeframe", "avg_abs_daily_move_pct", "avg_monthly_red_days",
+		"max_red_streak", "red_days_per_month_p50", "red_days_per_month_p90", "red_days_per_month_max",
+		"biggest_single_day_drop_pct", "max_drawdown_pct", "threshold_pct", "latest_move_pct", "error",
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, res := range results {
+		rec := toRecord(res)
+		row := []string{
+			rec.Ticker, rec.Timeframe,
+			strconv.FormatFloat(rec.AvgAbsDailyMove, 'f', 2, 64),
+			strconv.FormatFloat(rec.AvgMonthlyRedDays, 'f', 0, 64),
+			strconv.Itoa(rec.MaxRedStreak),
+			strconv.FormatFloat(rec.RedDaysP50, 'f', 1, 64),
+			strconv.FormatFloat(rec.RedDaysP90, 'f', 1, 64),
+			strconv.Itoa(rec.RedDaysMax),
+			strconv.FormatFloat(rec.BiggestDrop, 'f', 2, 64),
+			strconv.FormatFloat(rec.MaxDrawdownPct, 'f', 2, 64),
+			strconv.FormatFloat(rec.Threshold, 'f', 2, 64),
+			strconv.FormatFloat(rec.LatestMovePct, 'f', 2, 64),
+			rec.Error,
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	return w.Error()
+}
+
+// printMatrixView renders the compact table: rows are timeframes, columns
+// are tickers, one cell per (ticker, timeframe) result.
+func printMatrixView(out io.Writer, tickers, timeframes []string, results []TimeframeResult) {
+	matrix := make(map[string]map[string]string)
+	for _, tf := range timeframes {
+		matrix[tf] = make(map[string]string)
+	}
+	for _, res := range results {
+		if res.Err != nil {
+			matrix[res.Timeframe][res.Ticker] = "ERR"
+		} else {
+			matrix[res.Timeframe][res.Ticker] = fmt.Sprintf("Avg Abs: %.2f%% / Red: %.0f days", res.AvgAbsDailyMove, res.AvgMonthlyRedDays)
+		}
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	header := "Timeframe\t"
+	for _, tkr := range tickers {
+		header += fmt.Sprintf("%s\t", tkr)
+	}
+	fmt.Fprintln(w, header)
+	fmt.Fprintln(w, "---------\t"+strings.Repeat("---------\t", len(tickers)))
+	for _, tf := range timeframes {
+		row := fmt.Sprintf("%s\t", tf)
+		for _, tkr := range tickers {
+			cell, ok := matrix[tf][tkr]
+			if !ok {
+				cell = "N/A"
+			}
+			row += fmt.Sprintf("%s\t", cell)
+		}
+		fmt.Fprintln(w, row)
+	}
+	w.Flush()
+}
+
+// printNestedView renders the full per-(ticker, timeframe) breakdown,
+// including the extra red-day statistics the matrix view has no room for.
+func printNestedView(out io.Writer, tickers, timeframes []string, results []TimeframeResult) {
+	byTickerTimeframe := make(map[string]map[string]TimeframeResult)
+	for _, res := range results {
+		if byTickerTimeframe[res.Ticker] == nil {
+			byTickerTimeframe[res.Ticker] = make(map[string]TimeframeResult)
+		}
+		byTickerTimeframe[res.Ticker][res.Timeframe] = res
+	}
+
+	for _, tkr := range tickers {
+		fmt.Fprintf(out, "%s\n", tkr)
+		for _, tf := range timeframes {
+			res, ok := byTickerTimeframe[tkr][tf]
+			if !ok {
+				fmt.Fprintf(out, "  %s: N/A\n", tf)
+				continue
+			}
+			if res.Err != nil {
+				fmt.Fprintf(out, "  %s: ERR (%v)\n", tf, res.Err)
+				continue
+			}
+			fmt.Fprintf(out, "  %s:\n", tf)
+			fmt.Fprintf(out, "    avg abs daily move:   %.2f%%\n", res.AvgAbsDailyMove)
+			fmt.Fprintf(out, "    avg monthly red days:  %.0f\n", res.AvgMonthlyRedDays)
+			fmt.Fprintf(out, "    max red-day streak:    %d\n", res.MaxRedStreak)
+			fmt.Fprintf(out, "    red days/month p50/p90/max: %.1f / %.1f / %d\n", res.RedDaysP50, res.RedDaysP90, res.RedDaysMax)
+			fmt.Fprintf(out, "    biggest single-day drop: %.2f%%\n", res.BiggestDrop)
+			fmt.Fprintf(out, "    max drawdown:          %.2f%%\n", res.MaxDrawdownPct)
+		}
+	}
+}
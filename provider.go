@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Bar represents a single daily price bar from a market data provider.
+type Bar struct {
+	Timestamp time.Time
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Volume    int64
+}
+
+// MarketDataProvider fetches daily bars for a ticker over [from, to]. ctx
+// carries the per-request timeout and the run's global deadline.
+type MarketDataProvider interface {
+	FetchDaily(ctx context.Context, ticker string, from, to time.Time) ([]Bar, error)
+}
+
+// newHTTPClient builds the *http.Client shared by every provider, tuned for
+// the fan-out of concurrent per-ticker/per-timeframe requests this tool
+// makes.
+func newHTTPClient(concurrency int) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			MaxIdleConnsPerHost: concurrency,
+		},
+	}
+}
+
+// newProvider constructs the MarketDataProvider selected by the -provider flag,
+// pulling any required credentials from the environment.
+func newProvider(name string, client *http.Client) (MarketDataProvider, error) {
+	switch name {
+	case "", "yahoo":
+		return &YahooChartProvider{Client: client}, nil
+	case "yahoo-crumb":
+		return &YahooCrumbProvider{Client: client}, nil
+	case "alpaca":
+		keyID := os.Getenv("APCA_API_KEY_ID")
+		secret := os.Getenv("APCA_API_SECRET_KEY")
+		if keyID == "" || secret == "" {
+			return nil, fmt.Errorf("alpaca provider requires APCA_API_KEY_ID and APCA_API_SECRET_KEY to be set")
+		}
+		return &AlpacaProvider{Client: client, KeyID: keyID, Secret: secret}, nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q (want one of: yahoo, yahoo-crumb, alpaca)", name)
+	}
+}
+
+// rangeToDates converts a Yahoo-style range string (e.g. "ytd", "1y", "3y",
+// "5y", "10y") into an explicit [from, to] window ending now, so that every
+// MarketDataProvider can work off concrete dates instead of range strings.
+func rangeToDates(rangeStr string) (from, to time.Time, err error) {
+	to = time.Now()
+	switch rangeStr {
+	case "ytd":
+		from = time.Date(to.Year(), time.January, 1, 0, 0, 0, 0, to.Location())
+	case "1y":
+		from = to.AddDate(-1, 0, 0)
+	case "3y":
+		from = to.AddDate(-3, 0, 0)
+	case "5y":
+		from = to.AddDate(-5, 0, 0)
+	case "10y":
+		from = to.AddDate(-10, 0, 0)
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown range %q", rangeStr)
+	}
+	return from, to, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxRetries bounds how many times doWithRetry will retry a single request
+// before giving up and returning an error that wraps the last failure.
+const maxRetries = 5
+
+// doWithRetry performs req with client, retrying with exponential backoff
+// and jitter on 429 and 5xx responses. It honors a numeric Retry-After
+// header when the server sends one. The caller is responsible for closing
+// the returned response's body.
+func doWithRetry(client *http.Client, req *http.Request) (*http.Response, error) {
+	var lastErr error
+	backoff := 500 * time.Millisecond
+
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+		} else if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			wait := retryAfter(resp.Header.Get("Retry-After"), backoff)
+			resp.Body.Close()
+			lastErr = fmt.Errorf("received status code %d", resp.StatusCode)
+			if attempt == maxRetries {
+				break
+			}
+			time.Sleep(wait)
+			backoff *= 2
+			continue
+		} else {
+			return resp, nil
+		}
+
+		if attempt == maxRetries {
+			break
+		}
+		time.Sleep(backoff + jitter(backoff))
+		backoff *= 2
+	}
+
+	return nil, fmt.Errorf("giving up after %d attempts: %v", maxRetries+1, lastErr)
+}
+
+// retryAfter parses a Retry-After header (seconds form) if present,
+// otherwise falls back to backoff plus jitter.
+func retryAfter(header string, backoff time.Duration) time.Duration {
+	if header == "" {
+		return backoff + jitter(backoff)
+	}
+	seconds, err := strconv.Atoi(header)
+	if err != nil {
+		return backoff + jitter(backoff)
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// jitter returns a random duration in [0, d/2), so concurrent retries don't
+// all wake up and hammer the server at the same instant.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d) / 2))
+}
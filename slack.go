@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// slackBlockPayload is a minimal Slack Block Kit message: a summary block
+// plus one color-coded attachment per ticker.
+type slackBlockPayload struct {
+	Blocks      []slackBlock      `json:"blocks"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackBlock struct {
+	Type string     `json:"type"`
+	Text *slackText `json:"text,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackAttachment struct {
+	Color string `json:"color"`
+	Text  string `json:"text"`
+}
+
+const (
+	slackColorGreen   = "#2eb67d"
+	slackColorRed     = "#e01e5a"
+	slackColorNeutral = "#616061"
+)
+
+// slackPayload builds a Block Kit payload with one attachment per ticker,
+// colored by that ticker's latest daily move (from its "1y" result, falling
+// back to whatever else succeeded): red when the move is below its computed
+// red-day threshold, green when it is above the negated threshold, and
+// neutral otherwise.
+func slackPayload(tickers []string, results []TimeframeResult) slackBlockPayload {
+	best := make(map[string]TimeframeResult)
+	for _, res := range results {
+		if res.Err != nil {
+			continue
+		}
+		if existing, ok := best[res.Ticker]; !ok || (res.Timeframe == "1y" && existing.Timeframe != "1y") {
+			best[res.Ticker] = res
+		}
+	}
+
+	payload := slackBlockPayload{
+		Blocks: []slackBlock{
+			{
+				Type: "section",
+				Text: &slackText{Type: "mrkdwn", Text: "*Red-day tracker*"},
+			},
+		},
+	}
+
+	for _, tkr := range tickers {
+		res, ok := best[tkr]
+		if !ok {
+			payload.Attachments = append(payload.Attachments, slackAttachment{
+				Color: slackColorNeutral,
+				Text:  fmt.Sprintf(":bar_chart: *%s* no data available", tkr),
+			})
+			continue
+		}
+
+		emoji, color := ":bar_chart:", slackColorNeutral
+		switch {
+		case res.LatestMovePct < res.Threshold:
+			emoji, color = ":chart_with_downwards_trend:", slackColorRed
+		case res.LatestMovePct > -res.Threshold:
+			emoji, color = ":chart_with_upwards_trend:", slackColorGreen
+		}
+
+		payload.Attachments = append(payload.Attachments, slackAttachment{
+			Color: color,
+			Text:  fmt.Sprintf("%s *%s* %.2f%% (threshold %.2f%%)", emoji, tkr, res.LatestMovePct, res.Threshold),
+		})
+	}
+
+	return payload
+}
+
+// postSlackWebhook POSTs payload to a Slack incoming webhook URL. ctx bounds
+// the request with the caller's per-request timeout/deadline.
+func postSlackWebhook(ctx context.Context, webhookURL string, payload slackBlockPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error encoding Slack payload: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating HTTP request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting to Slack webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// thresholdSpec describes which red-day threshold model to apply, parsed
+// from the -threshold flag.
+type thresholdSpec struct {
+	kind string // "mean-abs", "stddev", "atr", or "percentile"
+	k    float64
+	p    float64
+}
+
+// parseThreshold parses the -threshold flag value. The empty string is
+// "mean-abs", matching the tool's original hardcoded behavior.
+func parseThreshold(s string) (thresholdSpec, error) {
+	if s == "" {
+		s = "mean-abs"
+	}
+	kind, arg, hasArg := strings.Cut(s, ":")
+	switch kind {
+	case "mean-abs":
+		return thresholdSpec{kind: kind}, nil
+	case "stddev":
+		if !hasArg {
+			return thresholdSpec{}, fmt.Errorf("threshold %q requires a k, e.g. stddev:2", s)
+		}
+		k, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return thresholdSpec{}, fmt.Errorf("invalid k in threshold %q: %v", s, err)
+		}
+		return thresholdSpec{kind: kind, k: k}, nil
+	case "atr":
+		if !hasArg {
+			return thresholdSpec{}, fmt.Errorf("threshold %q requires a k, e.g. atr:2", s)
+		}
+		k, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return thresholdSpec{}, fmt.Errorf("invalid k in threshold %q: %v", s, err)
+		}
+		return thresholdSpec{kind: kind, k: k}, nil
+	case "percentile":
+		if !hasArg {
+			return thresholdSpec{}, fmt.Errorf("threshold %q requires a percentile, e.g. percentile:5", s)
+		}
+		p, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return thresholdSpec{}, fmt.Errorf("invalid percentile in threshold %q: %v", s, err)
+		}
+		return thresholdSpec{kind: kind, p: p}, nil
+	default:
+		return thresholdSpec{}, fmt.Errorf("unknown threshold model %q (want one of: mean-abs, stddev:<k>, atr:<k>, percentile:<p>)", s)
+	}
+}
+
+// redDayThreshold computes the daily-move threshold below which a day is
+// considered "red", given the already-computed daily percentage moves and
+// the underlying bars (needed for the ATR model).
+func redDayThreshold(bars []Bar, dailyPct []float64, spec thresholdSpec) (float64, error) {
+	switch spec.kind {
+	case "mean-abs":
+		return -1 * meanAbs(dailyPct), nil
+	case "stddev":
+		mean, stddev := meanAndStddev(dailyPct)
+		return mean - spec.k*stddev, nil
+	case "atr":
+		atrPct, err := averageTrueRangePct(bars)
+		if err != nil {
+			return 0, err
+		}
+		return -spec.k * atrPct, nil
+	case "percentile":
+		return percentile(dailyPct, spec.p), nil
+	default:
+		return 0, fmt.Errorf("unknown threshold model %q", spec.kind)
+	}
+}
+
+func meanAbs(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += math.Abs(x)
+	}
+	return sum / float64(len(xs))
+}
+
+func meanAndStddev(xs []float64) (mean, stddev float64) {
+	if len(xs) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	mean = sum / float64(len(xs))
+
+	var variance float64
+	for _, x := range xs {
+		d := x - mean
+		variance += d * d
+	}
+	variance /= float64(len(xs))
+	return mean, math.Sqrt(variance)
+}
+
+// percentile returns the pth percentile (0-100) of xs using linear
+// interpolation between closest ranks.
+func percentile(xs []float64, p float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), xs...)
+	sort.Float64s(sorted)
+
+	if p <= 0 {
+		return sorted[0]
+	}
+	if p >= 100 {
+		return sorted[len(sorted)-1]
+	}
+
+	rank := (p / 100) * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	if lo == hi {
+		return sorted[lo]
+	}
+	frac := rank - float64(lo)
+	return sorted[lo]*(1-frac) + sorted[hi]*frac
+}
+
+// averageTrueRangePct computes a 14-period Average True Range over bars,
+// expressed as a percentage of the average close so it's comparable to the
+// percentage daily moves used elsewhere.
+func averageTrueRangePct(bars []Bar) (float64, error) {
+	const period = 14
+	if len(bars) < 2 {
+		return 0, fmt.Errorf("not enough bars to compute ATR")
+	}
+
+	var trueRanges []float64
+	var sumClose float64
+	for i, bar := range bars {
+		sumClose += bar.Close
+		if i == 0 {
+			continue
+		}
+		prevClose := bars[i-1].Close
+		tr := math.Max(bar.High-bar.Low, math.Max(math.Abs(bar.High-prevClose), math.Abs(bar.Low-prevClose)))
+		trueRanges = append(trueRanges, tr)
+	}
+	if len(trueRanges) == 0 {
+		return 0, fmt.Errorf("not enough bars to compute ATR")
+	}
+
+	n := period
+	if n > len(trueRanges) {
+		n = len(trueRanges)
+	}
+	recent := trueRanges[len(trueRanges)-n:]
+	var sumTR float64
+	for _, tr := range recent {
+		sumTR += tr
+	}
+	atr := sumTR / float64(n)
+	avgClose := sumClose / float64(len(bars))
+	if avgClose == 0 {
+		return 0, fmt.Errorf("average close is zero")
+	}
+	return (atr / avgClose) * 100, nil
+}
+
+// maxConsecutiveRedStreak returns the longest run of consecutive red days
+// among dailyPct, given threshold.
+func maxConsecutiveRedStreak(dailyPct []float64, threshold float64) int {
+	var maxStreak, current int
+	for _, pct := range dailyPct {
+		if pct < threshold {
+			current++
+			if current > maxStreak {
+				maxStreak = current
+			}
+		} else {
+			current = 0
+		}
+	}
+	return maxStreak
+}
+
+// maxDrawdownPct computes the largest peak-to-trough decline in closing
+// price over bars, as a percentage of the peak.
+func maxDrawdownPct(bars []Bar) float64 {
+	var peak, maxDD float64
+	for i, bar := range bars {
+		if i == 0 || bar.Close > peak {
+			peak = bar.Close
+		}
+		if peak == 0 {
+			continue
+		}
+		dd := ((bar.Close - peak) / peak) * 100
+		if dd < maxDD {
+			maxDD = dd
+		}
+	}
+	return maxDD
+}
+
+// monthlyRedDayStats summarizes the distribution of red-day counts across
+// calendar months: p50, p90 and the single worst month.
+func monthlyRedDayStats(redDaysByMonth map[string]int, monthsSeen map[string]int) (p50, p90 float64, max int) {
+	counts := make([]float64, 0, len(monthsSeen))
+	for month := range monthsSeen {
+		c := redDaysByMonth[month]
+		counts = append(counts, float64(c))
+		if c > max {
+			max = c
+		}
+	}
+	if len(counts) == 0 {
+		return 0, 0, 0
+	}
+	return percentile(counts, 50), percentile(counts, 90), max
+}
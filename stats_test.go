@@ -0,0 +1,168 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func bar(close float64) Bar {
+	return Bar{Open: close, High: close, Low: close, Close: close}
+}
+
+func TestParseThreshold(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    thresholdSpec
+		wantErr bool
+	}{
+		{"empty defaults to mean-abs", "", thresholdSpec{kind: "mean-abs"}, false},
+		{"mean-abs", "mean-abs", thresholdSpec{kind: "mean-abs"}, false},
+		{"stddev with k", "stddev:2", thresholdSpec{kind: "stddev", k: 2}, false},
+		{"stddev missing k", "stddev", thresholdSpec{}, true},
+		{"stddev invalid k", "stddev:nope", thresholdSpec{}, true},
+		{"atr with k", "atr:1.5", thresholdSpec{kind: "atr", k: 1.5}, false},
+		{"atr missing k", "atr", thresholdSpec{}, true},
+		{"percentile with p", "percentile:5", thresholdSpec{kind: "percentile", p: 5}, false},
+		{"percentile missing p", "percentile", thresholdSpec{}, true},
+		{"unknown model", "bogus", thresholdSpec{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseThreshold(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseThreshold(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("parseThreshold(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMeanAndStddev(t *testing.T) {
+	mean, stddev := meanAndStddev([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+	if math.Abs(mean-5) > 1e-9 {
+		t.Errorf("mean = %v, want 5", mean)
+	}
+	if math.Abs(stddev-2) > 1e-9 {
+		t.Errorf("stddev = %v, want 2", stddev)
+	}
+
+	if mean, stddev := meanAndStddev(nil); mean != 0 || stddev != 0 {
+		t.Errorf("meanAndStddev(nil) = %v, %v, want 0, 0", mean, stddev)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	xs := []float64{1, 2, 3, 4, 5}
+	tests := []struct {
+		p    float64
+		want float64
+	}{
+		{0, 1},
+		{50, 3},
+		{100, 5},
+		{-10, 1},
+		{110, 5},
+	}
+	for _, tt := range tests {
+		if got := percentile(xs, tt.p); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("percentile(xs, %v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile(nil, 50) = %v, want 0", got)
+	}
+}
+
+func TestRedDayThreshold(t *testing.T) {
+	dailyPct := []float64{1, -2, 3, -4}
+	bars := []Bar{bar(100), bar(102), bar(99), bar(103), bar(98)}
+
+	got, err := redDayThreshold(bars, dailyPct, thresholdSpec{kind: "mean-abs"})
+	if err != nil {
+		t.Fatalf("mean-abs: %v", err)
+	}
+	if want := -meanAbs(dailyPct); math.Abs(got-want) > 1e-9 {
+		t.Errorf("mean-abs threshold = %v, want %v", got, want)
+	}
+
+	if _, err := redDayThreshold(bars, dailyPct, thresholdSpec{kind: "bogus"}); err == nil {
+		t.Error("expected error for unknown threshold kind")
+	}
+}
+
+func TestMaxConsecutiveRedStreak(t *testing.T) {
+	tests := []struct {
+		name      string
+		dailyPct  []float64
+		threshold float64
+		want      int
+	}{
+		{"no red days", []float64{1, 2, 3}, -1, 0},
+		{"all red", []float64{-2, -3, -4}, -1, 3},
+		{"streak broken by a green day", []float64{-2, -3, 1, -2}, -1, 2},
+		{"empty", nil, -1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maxConsecutiveRedStreak(tt.dailyPct, tt.threshold); got != tt.want {
+				t.Errorf("maxConsecutiveRedStreak(%v, %v) = %d, want %d", tt.dailyPct, tt.threshold, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxDrawdownPct(t *testing.T) {
+	bars := []Bar{bar(100), bar(120), bar(90), bar(95), bar(150), bar(75)}
+	got := maxDrawdownPct(bars)
+	want := (75.0 - 150.0) / 150.0 * 100
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("maxDrawdownPct = %v, want %v", got, want)
+	}
+
+	if got := maxDrawdownPct(nil); got != 0 {
+		t.Errorf("maxDrawdownPct(nil) = %v, want 0", got)
+	}
+}
+
+func TestAverageTrueRangePct(t *testing.T) {
+	bars := []Bar{
+		{Timestamp: time.Unix(0, 0), Open: 100, High: 105, Low: 95, Close: 100},
+		{Timestamp: time.Unix(1, 0), Open: 100, High: 110, Low: 100, Close: 108},
+		{Timestamp: time.Unix(2, 0), Open: 108, High: 112, Low: 104, Close: 106},
+	}
+	got, err := averageTrueRangePct(bars)
+	if err != nil {
+		t.Fatalf("averageTrueRangePct: %v", err)
+	}
+	if got <= 0 {
+		t.Errorf("averageTrueRangePct = %v, want > 0", got)
+	}
+
+	if _, err := averageTrueRangePct(bars[:1]); err == nil {
+		t.Error("expected error for fewer than 2 bars")
+	}
+}
+
+func TestMonthlyRedDayStats(t *testing.T) {
+	monthsSeen := map[string]int{"2024-01": 20, "2024-02": 19, "2024-03": 21}
+	redDaysByMonth := map[string]int{"2024-01": 2, "2024-02": 5, "2024-03": 8}
+
+	p50, p90, max := monthlyRedDayStats(redDaysByMonth, monthsSeen)
+	if max != 8 {
+		t.Errorf("max = %d, want 8", max)
+	}
+	if p50 != percentile([]float64{2, 5, 8}, 50) {
+		t.Errorf("p50 = %v, want %v", p50, percentile([]float64{2, 5, 8}, 50))
+	}
+	if p90 != percentile([]float64{2, 5, 8}, 90) {
+		t.Errorf("p90 = %v, want %v", p90, percentile([]float64{2, 5, 8}, 90))
+	}
+
+	if p50, p90, max := monthlyRedDayStats(nil, nil); p50 != 0 || p90 != 0 || max != 0 {
+		t.Errorf("monthlyRedDayStats(nil, nil) = %v, %v, %v, want 0, 0, 0", p50, p90, max)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ChartResponse represents the JSON response from the Yahoo Finance chart API.
+type ChartResponse struct {
+	Chart struct {
+		Result []struct {
+			Timestamp  []int64 `json:"timestamp"`
+			Indicators struct {
+				Quote []struct {
+					Open   []float64 `json:"open"`
+					High   []float64 `json:"high"`
+					Low    []float64 `json:"low"`
+					Close  []float64 `json:"close"`
+					Volume []int64   `json:"volume"`
+				} `json:"quote"`
+			} `json:"indicators"`
+		} `json:"result"`
+		Error interface{} `json:"error"`
+	} `json:"chart"`
+}
+
+// YahooChartProvider fetches daily bars from Yahoo's unauthenticated
+// /v8/finance/chart endpoint.
+type YahooChartProvider struct {
+	Client *http.Client
+}
+
+func (p *YahooChartProvider) FetchDaily(ctx context.Context, ticker string, from, to time.Time) ([]Bar, error) {
+	bars, _, _, err := p.FetchDailyConditional(ctx, ticker, from, to, "")
+	return bars, err
+}
+
+// FetchDailyConditional fetches daily bars, sending If-Modified-Since when
+// lastModified is non-empty so the cache layer (see cache.go) can reuse the
+// previous response body on a 304 instead of re-downloading it.
+func (p *YahooChartProvider) FetchDailyConditional(ctx context.Context, ticker string, from, to time.Time, lastModified string) (bars []Bar, newLastModified string, notModified bool, err error) {
+	url := fmt.Sprintf("https://query1.finance.yahoo.com/v8/finance/chart/%s?period1=%d&period2=%d&interval=1d",
+		ticker, from.Unix(), to.Unix())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("error creating HTTP request: %v", err)
+	}
+	setYahooHeaders(req)
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	resp, err := doWithRetry(p.Client, req)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("error making HTTP request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, lastModified, true, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", false, fmt.Errorf("received status code %d", resp.StatusCode)
+	}
+
+	var chartResp ChartResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chartResp); err != nil {
+		return nil, "", false, fmt.Errorf("error decoding JSON: %v", err)
+	}
+	bars, err = barsFromChartResponse(chartResp)
+	if err != nil {
+		return nil, "", false, err
+	}
+	return bars, resp.Header.Get("Last-Modified"), false, nil
+}
+
+// barsFromChartResponse converts a decoded Yahoo chart payload into Bars,
+// shared by both the unauthenticated and crumb-authenticated Yahoo providers.
+func barsFromChartResponse(chartResp ChartResponse) ([]Bar, error) {
+	if len(chartResp.Chart.Result) == 0 {
+		return nil, fmt.Errorf("no result in chart response")
+	}
+
+	result := chartResp.Chart.Result[0]
+	timestamps := result.Timestamp
+	quote := result.Indicators.Quote[0]
+
+	if len(timestamps) != len(quote.Open) || len(timestamps) != len(quote.Close) {
+		return nil, fmt.Errorf("mismatch in data lengths")
+	}
+
+	bars := make([]Bar, 0, len(timestamps))
+	for i, ts := range timestamps {
+		bar := Bar{Timestamp: time.Unix(ts, 0)}
+		bar.Open = quote.Open[i]
+		bar.Close = quote.Close[i]
+		if i < len(quote.High) {
+			bar.High = quote.High[i]
+		}
+		if i < len(quote.Low) {
+			bar.Low = quote.Low[i]
+		}
+		if i < len(quote.Volume) {
+			bar.Volume = quote.Volume[i]
+		}
+		bars = append(bars, bar)
+	}
+	return bars, nil
+}
+
+// setYahooHeaders sets the headers Yahoo's endpoints expect from a browser
+// client; Yahoo has started rejecting requests that look scripted.
+func setYahooHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) "+
+		"AppleWebKit/537.36 (KHTML, like Gecko) Chrome/115.0.0.0 Safari/537.36")
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// crumbPattern extracts the crumb embedded in the quote page's CrumbStore.
+var crumbPattern = regexp.MustCompile(`"CrumbStore":\{"crumb":"(.*?)"\}`)
+
+// YahooCrumbProvider fetches daily bars the way Yahoo now requires: a crumb
+// and session cookie are scraped from the quote page, then reused against
+// /v7/finance/quote and /v8/finance/chart. A single instance is shared
+// across the worker pool's goroutines, so crumb/cookie are guarded by mu.
+type YahooCrumbProvider struct {
+	Client *http.Client
+
+	mu     sync.Mutex
+	crumb  string
+	cookie string
+}
+
+// credentials returns the current crumb and cookie under lock.
+func (p *YahooCrumbProvider) credentials() (crumb, cookie string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.crumb, p.cookie
+}
+
+// setCredentials replaces the current crumb and cookie under lock.
+func (p *YahooCrumbProvider) setCredentials(crumb, cookie string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.crumb, p.cookie = crumb, cookie
+}
+
+func (p *YahooCrumbProvider) FetchDaily(ctx context.Context, ticker string, from, to time.Time) ([]Bar, error) {
+	crumb, cookie := p.credentials()
+	if crumb == "" || cookie == "" {
+		if err := p.authenticate(ctx, ticker); err != nil {
+			return nil, fmt.Errorf("error authenticating with Yahoo: %v", err)
+		}
+	}
+
+	// Confirm the crumb is still accepted before pulling the chart; Yahoo
+	// ties the crumb to the /v7/finance/quote endpoint as well.
+	if err := p.verifyCrumb(ctx, ticker); err != nil {
+		return nil, fmt.Errorf("error verifying crumb: %v", err)
+	}
+	crumb, cookie = p.credentials()
+
+	url := fmt.Sprintf("https://query1.finance.yahoo.com/v8/finance/chart/%s?period1=%d&period2=%d&interval=1d&crumb=%s",
+		ticker, from.Unix(), to.Unix(), crumb)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating HTTP request: %v", err)
+	}
+	setYahooHeaders(req)
+	req.Header.Set("Cookie", cookie)
+
+	resp, err := doWithRetry(p.Client, req)
+	if err != nil {
+		return nil, fmt.Errorf("error making HTTP request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received status code %d", resp.StatusCode)
+	}
+
+	var chartResp ChartResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chartResp); err != nil {
+		return nil, fmt.Errorf("error decoding JSON: %v", err)
+	}
+	return barsFromChartResponse(chartResp)
+}
+
+// authenticate loads the quote page for ticker, extracts the CrumbStore
+// crumb, and remembers the "B" session cookie Yahoo sets on that response.
+func (p *YahooCrumbProvider) authenticate(ctx context.Context, ticker string) error {
+	url := fmt.Sprintf("https://finance.yahoo.com/quote/%s", ticker)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	setYahooHeaders(req)
+
+	resp, err := doWithRetry(p.Client, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	match := crumbPattern.FindSubmatch(body)
+	if match == nil {
+		return fmt.Errorf("could not find crumb in quote page")
+	}
+	crumb := string(match[1])
+
+	var cookie string
+	for _, c := range resp.Cookies() {
+		if c.Name == "B" {
+			cookie = fmt.Sprintf("%s=%s", c.Name, c.Value)
+			break
+		}
+	}
+	if cookie == "" {
+		return fmt.Errorf("could not find B cookie in quote page response")
+	}
+	p.setCredentials(crumb, cookie)
+	return nil
+}
+
+// verifyCrumb calls /v7/finance/quote, which Yahoo rejects if the crumb and
+// cookie are stale, surfacing auth failures before the chart request.
+func (p *YahooCrumbProvider) verifyCrumb(ctx context.Context, ticker string) error {
+	crumb, cookie := p.credentials()
+	url := fmt.Sprintf("https://query1.finance.yahoo.com/v7/finance/quote?symbols=%s&crumb=%s", ticker, crumb)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	setYahooHeaders(req)
+	req.Header.Set("Cookie", cookie)
+
+	resp, err := doWithRetry(p.Client, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		p.setCredentials("", "")
+		return p.authenticate(ctx, ticker)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("received status code %d", resp.StatusCode)
+	}
+	return nil
+}